@@ -0,0 +1,45 @@
+// Command jsonschemagen generates Go type declarations from a JSON Schema
+// document.
+//
+// usage: jsonschemagen <schema.json> <output.go> <package>
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/wshaman/go-jsonschema-generator/codegen"
+)
+
+func main() {
+	if len(os.Args) != 4 {
+		fmt.Fprintln(os.Stderr, "usage: jsonschemagen <schema.json> <output.go> <package>")
+		os.Exit(1)
+	}
+	schemaPath, outPath, pkg := os.Args[1], os.Args[2], os.Args[3]
+
+	if err := run(schemaPath, outPath, pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "jsonschemagen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(schemaPath, outPath, pkg string) error {
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return err
+	}
+
+	schema, err := codegen.ParseSchema(data)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return codegen.NewGenerator(pkg).Generate(out, schema)
+}
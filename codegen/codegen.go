@@ -0,0 +1,415 @@
+/*
+Package codegen generates Go type declarations from a JSON Schema document -
+the reverse of what the top-level jsonschema package does. It covers the
+common shapes: object schemas become structs, $ref/$defs become named
+types, enums become typed string constants, and oneOf/anyOf become marker
+interfaces implemented by their $ref'd alternatives.
+*/
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Schema is a parsed JSON Schema document, covering the subset of keywords
+// needed to drive Go type generation (in particular, everything the
+// top-level jsonschema package itself produces).
+type Schema struct {
+	Type        string             `json:"type,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Enum        []interface{}      `json:"enum,omitempty"`
+	OneOf       []*Schema          `json:"oneOf,omitempty"`
+	AnyOf       []*Schema          `json:"anyOf,omitempty"`
+	Definitions map[string]*Schema `json:"definitions,omitempty"`
+	Defs        map[string]*Schema `json:"$defs,omitempty"`
+}
+
+func (s *Schema) definitions() map[string]*Schema {
+	if len(s.Defs) > 0 {
+		return s.Defs
+	}
+	return s.Definitions
+}
+
+// ParseSchema decodes a JSON Schema document.
+func ParseSchema(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("codegen: parse schema: %w", err)
+	}
+	return &s, nil
+}
+
+// Generator emits idiomatic Go type declarations for a parsed JSON Schema
+// document.
+type Generator struct {
+	Package string
+
+	imports map[string]bool
+	pending []ast.Decl
+}
+
+// NewGenerator creates a Generator that emits into the given package.
+func NewGenerator(pkg string) *Generator {
+	return &Generator{Package: pkg}
+}
+
+// Generate writes Go source declaring types for schema and everything under
+// its $defs/definitions to w.
+func (g *Generator) Generate(w io.Writer, schema *Schema) error {
+	g.imports = make(map[string]bool)
+	g.pending = nil
+
+	var decls []ast.Decl
+
+	defs := schema.definitions()
+	used := make(map[string]bool, len(defs))
+	for _, name := range sortedKeys(defs) {
+		exported := exportedName(name)
+		used[exported] = true
+		d, err := g.declFor(exported, defs[name])
+		if err != nil {
+			return fmt.Errorf("codegen: type %s: %w", name, err)
+		}
+		decls = append(decls, d...)
+	}
+
+	if schema.Ref == "" {
+		rootName := "Root"
+		if schema.Title != "" {
+			rootName = exportedName(schema.Title)
+		}
+		rootName = uniqueName(used, rootName)
+		d, err := g.declFor(rootName, schema)
+		if err != nil {
+			return fmt.Errorf("codegen: root type: %w", err)
+		}
+		decls = append(decls, d...)
+	}
+	decls = append(decls, g.pending...)
+
+	file := &ast.File{Name: ast.NewIdent(g.Package)}
+	if len(g.imports) > 0 {
+		paths := sortedSet(g.imports)
+		specs := make([]ast.Spec, len(paths))
+		for i, path := range paths {
+			specs[i] = &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(path)}}
+		}
+		file.Decls = append(file.Decls, &ast.GenDecl{Tok: token.IMPORT, Specs: specs})
+	}
+	file.Decls = append(file.Decls, decls...)
+
+	fset := token.NewFileSet()
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	return cfg.Fprint(w, fset, file)
+}
+
+// declFor returns the declaration(s) needed to define name: a single type
+// decl for structs/aliases, or a type+const pair for enums, or an
+// interface+marker-methods set for oneOf/anyOf.
+func (g *Generator) declFor(name string, s *Schema) ([]ast.Decl, error) {
+	switch {
+	case len(s.Enum) > 0:
+		return g.enumDecls(name, s)
+	case len(s.OneOf) > 0:
+		return g.sumTypeDecls(name, s.OneOf)
+	case len(s.AnyOf) > 0:
+		return g.sumTypeDecls(name, s.AnyOf)
+	}
+
+	typeExpr, err := g.typeExprFor(name, s)
+	if err != nil {
+		return nil, err
+	}
+
+	decl := &ast.GenDecl{
+		Tok:   token.TYPE,
+		Specs: []ast.Spec{&ast.TypeSpec{Name: ast.NewIdent(name), Type: typeExpr}},
+	}
+	if s.Description != "" {
+		decl.Doc = &ast.CommentGroup{List: []*ast.Comment{{Text: "// " + name + " " + s.Description}}}
+	}
+	return []ast.Decl{decl}, nil
+}
+
+func (g *Generator) enumDecls(name string, s *Schema) ([]ast.Decl, error) {
+	typeDecl := &ast.GenDecl{
+		Tok:   token.TYPE,
+		Specs: []ast.Spec{&ast.TypeSpec{Name: ast.NewIdent(name), Type: ast.NewIdent("string")}},
+	}
+
+	constDecl := &ast.GenDecl{Tok: token.CONST}
+	for _, v := range s.Enum {
+		str, ok := v.(string)
+		if !ok {
+			str = fmt.Sprintf("%v", v)
+		}
+		constDecl.Specs = append(constDecl.Specs, &ast.ValueSpec{
+			Names:  []*ast.Ident{ast.NewIdent(name + exportedName(str))},
+			Type:   ast.NewIdent(name),
+			Values: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(str)}},
+		})
+	}
+	return []ast.Decl{typeDecl, constDecl}, nil
+}
+
+// sumTypeDecls turns a oneOf/anyOf into a marker interface, implemented by
+// whichever alternatives are themselves $refs to a named type. Inline
+// alternatives without a $ref can't be given a method, so they're skipped.
+func (g *Generator) sumTypeDecls(name string, alts []*Schema) ([]ast.Decl, error) {
+	marker := "is" + name
+
+	iface := &ast.GenDecl{
+		Tok: token.TYPE,
+		Specs: []ast.Spec{&ast.TypeSpec{
+			Name: ast.NewIdent(name),
+			Type: &ast.InterfaceType{Methods: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{ast.NewIdent(marker)}, Type: &ast.FuncType{Params: &ast.FieldList{}}},
+			}}},
+		}},
+	}
+
+	decls := []ast.Decl{iface}
+	for _, alt := range alts {
+		if alt.Ref == "" {
+			continue
+		}
+		decls = append(decls, &ast.FuncDecl{
+			Recv: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{ast.NewIdent("_")}, Type: ast.NewIdent(refName(alt.Ref))},
+			}},
+			Name: ast.NewIdent(marker),
+			Type: &ast.FuncType{Params: &ast.FieldList{}},
+			Body: &ast.BlockStmt{},
+		})
+	}
+	return decls, nil
+}
+
+// typeExprFor resolves the Go type for a schema node: a $ref becomes the
+// referenced type's name, an enum is hoisted into its own named string type
+// (queued onto g.pending), a map-shaped object (the ".*" pattern-property
+// convention the forward direction emits for every Go map) becomes
+// map[string]T, object schemas with properties become an inline struct, and
+// everything else maps through the same format table the forward direction
+// uses. name seeds the identifier used for anything that has to be hoisted
+// out into its own declaration (an enum, today).
+func (g *Generator) typeExprFor(name string, s *Schema) (ast.Expr, error) {
+	if s.Ref != "" {
+		return ast.NewIdent(refName(s.Ref)), nil
+	}
+
+	if len(s.Enum) > 0 {
+		decls, err := g.enumDecls(name, s)
+		if err != nil {
+			return nil, err
+		}
+		g.pending = append(g.pending, decls...)
+		return ast.NewIdent(name), nil
+	}
+
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = true
+			return &ast.SelectorExpr{X: ast.NewIdent("time"), Sel: ast.NewIdent("Time")}, nil
+		case "uri":
+			g.imports["net/url"] = true
+			return &ast.StarExpr{X: &ast.SelectorExpr{X: ast.NewIdent("url"), Sel: ast.NewIdent("URL")}}, nil
+		default:
+			return ast.NewIdent("string"), nil
+		}
+	case "integer":
+		return ast.NewIdent("int64"), nil
+	case "number":
+		return ast.NewIdent("float64"), nil
+	case "boolean":
+		return ast.NewIdent("bool"), nil
+	case "array":
+		if s.Items == nil {
+			return &ast.ArrayType{Elt: emptyInterface()}, nil
+		}
+		elem, err := g.typeExprFor(name+"Item", s.Items)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.ArrayType{Elt: elem}, nil
+	case "object":
+		if value, ok := patternPropertyValue(s); ok {
+			valType, err := g.typeExprFor(name+"Value", value)
+			if err != nil {
+				return nil, err
+			}
+			return &ast.MapType{Key: ast.NewIdent("string"), Value: valType}, nil
+		}
+		if len(s.Properties) > 0 {
+			fields, err := g.structFields(name, s)
+			if err != nil {
+				return nil, err
+			}
+			return &ast.StructType{Fields: fields}, nil
+		}
+		return &ast.MapType{Key: ast.NewIdent("string"), Value: emptyInterface()}, nil
+	default:
+		return emptyInterface(), nil
+	}
+}
+
+// patternPropertyValue reports whether s is the map-shaped object the
+// forward direction emits for every Go map: a single pattern entry under
+// the literal key ".*" rather than a real patternProperties keyword.
+func patternPropertyValue(s *Schema) (*Schema, bool) {
+	if len(s.Properties) != 1 {
+		return nil, false
+	}
+	value, ok := s.Properties[".*"]
+	return value, ok
+}
+
+func (g *Generator) structFields(parentName string, s *Schema) (*ast.FieldList, error) {
+	required := make(map[string]bool, len(s.Required))
+	for _, r := range s.Required {
+		required[r] = true
+	}
+
+	fields := &ast.FieldList{}
+	for _, name := range sortedKeys(s.Properties) {
+		prop := s.Properties[name]
+		fieldName := exportedName(name)
+
+		typeExpr, err := g.typeExprFor(parentName+fieldName, prop)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+
+		tagValue := name
+		if !required[name] {
+			if needsPointerWrap(typeExpr) {
+				typeExpr = &ast.StarExpr{X: typeExpr}
+			}
+			tagValue += ",omitempty"
+		}
+
+		fields.List = append(fields.List, &ast.Field{
+			Names: []*ast.Ident{ast.NewIdent(fieldName)},
+			Type:  typeExpr,
+			Tag:   &ast.BasicLit{Kind: token.STRING, Value: "`json:\"" + tagValue + "\"`"},
+		})
+	}
+	return fields, nil
+}
+
+// needsPointerWrap reports whether an optional field of this type needs a
+// *T to be nil-able. Slices and maps are already nil-able without one, and
+// a type that's already a pointer (e.g. *url.URL for an optional uri
+// format) shouldn't be wrapped a second time.
+func needsPointerWrap(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.StarExpr, *ast.ArrayType, *ast.MapType:
+		return false
+	default:
+		return true
+	}
+}
+
+func emptyInterface() ast.Expr {
+	return &ast.InterfaceType{Methods: &ast.FieldList{}}
+}
+
+// refName extracts the type name out of a "#/$defs/Name" or
+// "#/definitions/Name" JSON pointer.
+func refName(ref string) string {
+	idx := strings.LastIndex(ref, "/")
+	if idx == -1 {
+		return exportedName(ref)
+	}
+	return exportedName(ref[idx+1:])
+}
+
+// exportedName turns an arbitrary schema identifier (a property name, an
+// enum value, a $defs key) into an exported Go identifier. Word separators
+// (_, -, space, .) start a new capitalized word; anything else that can't
+// appear in a Go identifier (*, $, etc. - notably the ".*" pattern-property
+// marker) is dropped rather than passed through, so the result is always a
+// valid identifier.
+func exportedName(s string) string {
+	var b strings.Builder
+	upperNext := true
+	for _, r := range s {
+		switch {
+		case r == '_' || r == '-' || r == ' ' || r == '.':
+			upperNext = true
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if upperNext {
+				b.WriteRune(unicode.ToUpper(r))
+				upperNext = false
+			} else {
+				b.WriteRune(r)
+			}
+		default:
+			upperNext = true
+		}
+	}
+
+	name := b.String()
+	if name == "" {
+		return "Field"
+	}
+	if unicode.IsDigit(rune(name[0])) {
+		return "Field" + name
+	}
+	return name
+}
+
+// uniqueName returns name, or a disambiguated variant of it, that isn't in
+// used - needed because the root type's name (its title, or the "Root"
+// default) can otherwise collide with a same-named $defs entry and produce
+// two conflicting declarations of the same Go identifier.
+func uniqueName(used map[string]bool, name string) string {
+	if !used[name] {
+		return name
+	}
+	if candidate := name + "Root"; !used[candidate] {
+		return candidate
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s%d", name, i)
+		if !used[candidate] {
+			return candidate
+		}
+	}
+}
+
+func sortedKeys(m map[string]*Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedSet(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/wshaman/go-jsonschema-generator"
 )
@@ -16,7 +17,7 @@ type Item struct {
 
 type ExampleBasic struct {
 	Foo bool   `json:"foo"`
-	Bar string `json:",omitempty,minLength:1"`
+	Bar string `json:",omitempty" jsonschema:"minLength=1"`
 	Qux int8
 	Baz []string
 	EmbeddedType
@@ -25,6 +26,8 @@ type ExampleBasic struct {
 
 func main() {
 	s := &jsonschema.Document{}
-	s.Read(&ExampleBasic{})
+	if err := s.Read(&ExampleBasic{}); err != nil {
+		log.Fatal(err)
+	}
 	fmt.Println(s)
 }
@@ -7,30 +7,184 @@ package jsonschema
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 const DefaultSchema = "http://json-schema.org/schema#"
 
+// Draft selects which JSON Schema draft a Document is rendered as. The zero
+// value, DraftUnspecified, keeps the generic, version-less $schema URI and
+// the $defs keyword that this package has always used.
+type Draft int
+
+const (
+	DraftUnspecified Draft = iota
+	Draft04
+	Draft07
+	Draft2019_09
+	Draft2020_12
+)
+
+func (d Draft) schemaURI() string {
+	switch d {
+	case Draft04:
+		return "http://json-schema.org/draft-04/schema#"
+	case Draft07:
+		return "http://json-schema.org/draft-07/schema#"
+	case Draft2019_09:
+		return "https://json-schema.org/draft/2019-09/schema"
+	case Draft2020_12:
+		return "https://json-schema.org/draft/2020-12/schema"
+	default:
+		return DefaultSchema
+	}
+}
+
+// defsKeyword returns the keyword a draft stores named definitions under:
+// "definitions" pre-dates "$defs", which draft-2019-09 introduced.
+func (d Draft) defsKeyword() string {
+	switch d {
+	case Draft04, Draft07:
+		return "definitions"
+	default:
+		return "$defs"
+	}
+}
+
 type Document struct {
-	Schema string `json:"$schema,omitempty"`
+	Schema      string
+	Draft       Draft
+	Definitions map[string]*property
 	property
+
+	typeMapper TypeMapper
+	registry   map[reflect.Type]*property
+}
+
+// Property is the exported name for the schema node type, used by
+// TypeMapper implementations and Document.RegisterType to describe how a
+// type should be represented without having to recurse into its fields.
+type Property = property
+
+// TypeMapper lets callers teach a Document how to render their own types,
+// for cases a built-in mapping can't cover. MapType is consulted for every
+// type the generator encounters; returning ok == false falls back to
+// Document.RegisterType entries and then the built-in mappings.
+type TypeMapper interface {
+	MapType(t reflect.Type) (*Property, bool)
+}
+
+// Option configures a Document created via NewDocument.
+type Option func(*Document)
+
+// WithTypeMapper installs a TypeMapper consulted for every type the
+// generator encounters, before the built-in mappings.
+func WithTypeMapper(m TypeMapper) Option {
+	return func(d *Document) {
+		d.typeMapper = m
+	}
+}
+
+// WithDraft selects which JSON Schema draft the Document renders as.
+func WithDraft(draft Draft) Option {
+	return func(d *Document) {
+		d.Draft = draft
+	}
+}
+
+// NewDocument creates a Document configured with the given options.
+func NewDocument(opts ...Option) *Document {
+	d := &Document{}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// RegisterType overrides the schema produced for t, so callers can describe
+// domain types (or replace a built-in mapping) without forking the package.
+// schema is used as-is and should be a leaf/terminal schema - it is not
+// walked any further.
+func (d *Document) RegisterType(t reflect.Type, schema *Property) {
+	if d.registry == nil {
+		d.registry = make(map[reflect.Type]*property)
+	}
+	d.registry[t] = schema
 }
 
-// Reads the variable structure into the JSON-Schema Document
-func (d *Document) Read(variable interface{}) {
+// Read reads the variable structure into the JSON-Schema Document. It
+// returns an error if a `jsonschema` struct tag could not be parsed.
+//
+// The entry type itself is always rendered inline: it's only ever pushed
+// into $defs (and replaced by a $ref) if something else in the tree refers
+// back to it. Every other named struct type is $ref'd on first encounter,
+// as usual.
+func (d *Document) Read(variable interface{}) error {
 	d.setDefaultSchema()
 
 	value := reflect.ValueOf(variable)
-	d.read(value.Type(), tagOptions(""))
+	ctx := newReadContext()
+	ctx.registry = d.registry
+	ctx.mapper = d.typeMapper
+	ctx.draft = d.Draft
+
+	t := value.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Struct && t.Name() != "" {
+		_, def, _ := ctx.registerDef(t)
+		if err := def.readFromStruct(t, ctx); err != nil {
+			return err
+		}
+		d.property = *def
+	} else if err := d.read(t, tagOptions(""), ctx); err != nil {
+		return err
+	}
+
+	// Only types some $ref actually points at belong in $defs - the entry
+	// type itself was expanded inline above and registered solely so a
+	// self-reference back to it has somewhere to point.
+	for name := range ctx.defs {
+		if !ctx.referenced[name] {
+			delete(ctx.defs, name)
+		}
+	}
+	if len(ctx.defs) > 0 {
+		d.Definitions = ctx.defs
+	}
+	return nil
 }
 
 func (d *Document) setDefaultSchema() {
 	if d.Schema == "" {
-		d.Schema = DefaultSchema
+		d.Schema = d.Draft.schemaURI()
+	}
+}
+
+// MarshalJSON renders the Document per its selected Draft: the $schema URI,
+// the $defs/definitions keyword, and validation keywords gated to what that
+// draft actually supports (e.g. numeric vs. boolean exclusiveMinimum).
+func (d *Document) MarshalJSON() ([]byte, error) {
+	d.setDefaultSchema()
+
+	out := d.property.toMap(d.Draft)
+	out["$schema"] = d.Schema
+
+	if len(d.Definitions) > 0 {
+		defs := make(map[string]interface{}, len(d.Definitions))
+		for name, def := range d.Definitions {
+			defs[name] = def.toMap(d.Draft)
+		}
+		out[d.Draft.defsKeyword()] = defs
 	}
+
+	return json.Marshal(out)
 }
 
 // Marshal returns the JSON encoding of the Document
@@ -46,17 +200,78 @@ func (d *Document) String() string {
 
 type property struct {
 	Type                 string               `json:"type,omitempty"`
-	MinLength            *int                 `json:"minLength,omitempty"`
-	MaxLength            *int                 `json:"maxLength,omitempty"`
+	Ref                  string               `json:"$ref,omitempty"`
 	Format               string               `json:"format,omitempty"`
 	Items                *property            `json:"items,omitempty"`
 	Properties           map[string]*property `json:"properties,omitempty"`
 	Required             []string             `json:"required,omitempty"`
 	AdditionalProperties bool                 `json:"additionalProperties,omitempty"`
+
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       *float64 `json:"multipleOf,omitempty"`
+
+	Enum  []interface{} `json:"enum,omitempty"`
+	Const interface{}   `json:"const,omitempty"`
+
+	MinItems    *int `json:"minItems,omitempty"`
+	MaxItems    *int `json:"maxItems,omitempty"`
+	UniqueItems bool `json:"uniqueItems,omitempty"`
+
+	MinProperties *int `json:"minProperties,omitempty"`
+	MaxProperties *int `json:"maxProperties,omitempty"`
+
+	Default   interface{}   `json:"default,omitempty"`
+	Examples  []interface{} `json:"examples,omitempty"`
+	ReadOnly  bool          `json:"readOnly,omitempty"`
+	WriteOnly bool          `json:"writeOnly,omitempty"`
+
+	// arrayLen is set by readFromArray for fixed-length Go arrays ([N]T); it
+	// drives the prefixItems/items-tuple rendering in toMap.
+	arrayLen int
+}
+
+// readContext carries the state that must survive across recursive calls to
+// property.read: the set of named types already visited (so self-referential
+// types don't recurse forever) and the $defs collected along the way.
+type readContext struct {
+	seen       map[string]string
+	defs       map[string]*property
+	referenced map[string]bool
+	registry   map[reflect.Type]*property
+	mapper     TypeMapper
+	draft      Draft
+}
+
+func newReadContext() *readContext {
+	return &readContext{
+		seen:       make(map[string]string),
+		defs:       make(map[string]*property),
+		referenced: make(map[string]bool),
+	}
 }
 
-func (p *property) read(t reflect.Type, opts tagOptions) {
+func (p *property) read(t reflect.Type, opts tagOptions, ctx *readContext) error {
+	if override, ok := resolveOverride(t, ctx); ok {
+		*p = *override
+		return nil
+	}
+
 	jsType, format, kind := getTypeFromMapping(t)
+
+	if kind == reflect.Struct && t.Name() != "" {
+		return p.readNamedStruct(t, ctx)
+	}
+
 	if jsType != "" {
 		p.Type = jsType
 	}
@@ -66,38 +281,154 @@ func (p *property) read(t reflect.Type, opts tagOptions) {
 
 	switch kind {
 	case reflect.Slice:
-		p.readFromSlice(t)
+		return p.readFromSlice(t, ctx)
+	case reflect.Array:
+		return p.readFromArray(t, ctx)
 	case reflect.Map:
-		p.readFromMap(t)
+		return p.readFromMap(t, ctx)
 	case reflect.Struct:
-		p.readFromStruct(t)
+		return p.readFromStruct(t, ctx)
 	case reflect.Ptr:
-		p.read(t.Elem(), opts)
+		return p.read(t.Elem(), opts, ctx)
 	}
+	return nil
 }
 
-func (p *property) readFromSlice(t reflect.Type) {
-	jsType, _, kind := getTypeFromMapping(t.Elem())
-	if kind == reflect.Uint8 {
-		p.Type = "string"
-	} else if jsType != "" {
-		p.Items = &property{}
-		p.Items.read(t.Elem(), tagOptions(""))
+// readNamedStruct registers t under the draft's definitions keyword (once)
+// and turns p into a $ref pointing at it, so repeated and self-referential
+// types are only expanded once instead of being inlined over and over (or
+// recursing forever).
+func (p *property) readNamedStruct(t reflect.Type, ctx *readContext) error {
+	name, def, existed := ctx.registerDef(t)
+	if !existed {
+		if err := def.readFromStruct(t, ctx); err != nil {
+			return err
+		}
 	}
+
+	ctx.referenced[name] = true
+	p.Ref = "#/" + ctx.draft.defsKeyword() + "/" + name
+	return nil
 }
 
-func (p *property) readFromMap(t reflect.Type) {
-	jsType, format, _ := getTypeFromMapping(t.Elem())
+// registerDef returns the $defs entry for t, creating it on first use. The
+// bool reports whether t had already been registered, so callers that need
+// to expand it themselves (readNamedStruct, the Document's own entry type)
+// know whether that's still their job.
+func (ctx *readContext) registerDef(t reflect.Type) (name string, def *property, existed bool) {
+	key := t.PkgPath() + "." + t.Name()
+	if name, ok := ctx.seen[key]; ok {
+		return name, ctx.defs[name], true
+	}
 
-	if jsType != "" {
-		p.Properties = make(map[string]*property, 0)
-		p.Properties[".*"] = &property{Type: jsType, Format: format}
-	} else {
-		p.AdditionalProperties = true
+	name = uniqueDefName(ctx.defs, t)
+	ctx.seen[key] = name
+
+	def = &property{}
+	ctx.defs[name] = def
+	return name, def, false
+}
+
+// uniqueDefName picks a $defs name for t that won't collide with a
+// different type already registered under its bare name - which otherwise
+// happens whenever two packages export a same-named type. Falls back to a
+// package-qualified name, then an incrementing suffix, as a last resort.
+func uniqueDefName(defs map[string]*property, t reflect.Type) string {
+	name := t.Name()
+	if _, taken := defs[name]; !taken {
+		return name
+	}
+
+	if pkg := t.PkgPath(); pkg != "" {
+		if idx := strings.LastIndex(pkg, "/"); idx != -1 {
+			pkg = pkg[idx+1:]
+		}
+		candidate := exportedPrefix(pkg) + name
+		if _, taken := defs[candidate]; !taken {
+			return candidate
+		}
 	}
+
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s%d", name, i)
+		if _, taken := defs[candidate]; !taken {
+			return candidate
+		}
+	}
+}
+
+// exportedPrefix upper-cases the leading rune of a package name so it reads
+// as part of an exported Go identifier (e.g. "models" -> "Models").
+func exportedPrefix(s string) string {
+	if s == "" {
+		return ""
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// resolveElem peels off pointer indirections from a slice/array/map element
+// type before it's handed to a recursive read, so shapes like []*Person or
+// map[string]*Person resolve the pointed-to type instead of bottoming out
+// empty. The bool reports whether the (fully unwrapped) element is a byte,
+// letting callers keep the []byte-as-string short-circuit.
+func resolveElem(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, t.Kind() == reflect.Uint8
+}
+
+// readFromArray handles fixed-length Go arrays ([N]T), which - unlike
+// slices - have a known length the schema can pin down exactly.
+func (p *property) readFromArray(t reflect.Type, ctx *readContext) error {
+	p.Type = "array"
+	p.arrayLen = t.Len()
+
+	elem, _ := resolveElem(t.Elem())
+	p.Items = &property{}
+	if err := p.Items.read(elem, tagOptions(""), ctx); err != nil {
+		return err
+	}
+
+	n := t.Len()
+	p.MinItems = &n
+	p.MaxItems = &n
+	return nil
+}
+
+func (p *property) readFromSlice(t reflect.Type, ctx *readContext) error {
+	elem, isByte := resolveElem(t.Elem())
+	if isByte {
+		p.Type = "string"
+		return nil
+	}
+
+	p.Items = &property{}
+	return p.Items.read(elem, tagOptions(""), ctx)
+}
+
+// readFromMap recurses fully into the map's value type (peeling pointers,
+// walking structs/slices/maps) so nested shapes like map[string][]*Person
+// or map[string]map[string]T get a real schema instead of a bare "object".
+// Values are still expressed the way this package always has: a single
+// pattern entry under the literal key ".*" rather than patternProperties.
+func (p *property) readFromMap(t reflect.Type, ctx *readContext) error {
+	elem, isByte := resolveElem(t.Elem())
+
+	value := &property{}
+	if isByte {
+		value.Type = "string"
+	} else if err := value.read(elem, tagOptions(""), ctx); err != nil {
+		return err
+	}
+
+	p.Properties = map[string]*property{".*": value}
+	return nil
 }
 
-func (p *property) readFromStruct(t reflect.Type) {
+func (p *property) readFromStruct(t reflect.Type, ctx *readContext) error {
 	p.Type = "object"
 	p.Properties = make(map[string]*property, 0)
 	p.AdditionalProperties = false
@@ -116,8 +447,23 @@ func (p *property) readFromStruct(t reflect.Type) {
 		}
 
 		if field.Anonymous {
+			embeddedType := field.Type
+			for embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+
 			embeddedProperty := &property{}
-			embeddedProperty.read(field.Type, opts)
+			if embeddedType.Kind() == reflect.Struct {
+				// Expand inline via readFromStruct directly, bypassing
+				// read()'s named-struct handling - an embedded struct's
+				// fields are promoted onto p by encoding/json, they don't
+				// belong behind a $ref of their own.
+				if err := embeddedProperty.readFromStruct(embeddedType, ctx); err != nil {
+					return err
+				}
+			} else if err := embeddedProperty.read(field.Type, opts, ctx); err != nil {
+				return err
+			}
 
 			for name, property := range embeddedProperty.Properties {
 				p.Properties[name] = property
@@ -127,46 +473,384 @@ func (p *property) readFromStruct(t reflect.Type) {
 			continue
 		}
 
-		p.Properties[name] = &property{}
-		p.Properties[name].read(field.Type, opts)
-		p.Properties[name].applyOpts(opts)
+		fieldProperty := &property{}
+		if err := fieldProperty.read(field.Type, opts, ctx); err != nil {
+			return err
+		}
+		if opts.Contains("string") {
+			applyStringTagOption(fieldProperty)
+		}
+		if tag, ok := field.Tag.Lookup("jsonschema"); ok {
+			if err := fieldProperty.applyJSONSchemaTag(tag); err != nil {
+				return fmt.Errorf("jsonschema: field %s.%s: %w", t.Name(), field.Name, err)
+			}
+		}
+		p.Properties[name] = fieldProperty
 
 		if !opts.Contains("omitempty") {
 			p.Required = append(p.Required, name)
 		}
 	}
+	return nil
+}
+
+// applyStringTagOption rewrites a numeric/boolean property to the string it
+// actually marshals as when the field carries the encoding/json `,string`
+// option (e.g. `json:",string"`), so the schema matches the wire format
+// instead of the Go type.
+func applyStringTagOption(p *property) {
+	switch p.Type {
+	case "integer":
+		p.Type = "string"
+		p.Pattern = `^-?[0-9]+$`
+	case "number":
+		p.Type = "string"
+		p.Pattern = `^-?[0-9]+(\.[0-9]+)?$`
+	case "boolean":
+		p.Type = "string"
+		p.Pattern = `^(true|false)$`
+	}
+}
+
+// applyJSONSchemaTag parses the comma-separated key=value pairs carried by a
+// `jsonschema:"..."` struct tag and applies them to p, independently of the
+// encoding/json tag used for the field name and `omitempty`.
+func (p *property) applyJSONSchemaTag(tag string) error {
+	for _, part := range strings.Split(tag, ",") {
+		if part == "" {
+			continue
+		}
+
+		key, val := part, ""
+		if idx := strings.Index(part, "="); idx != -1 {
+			key, val = part[:idx], part[idx+1:]
+		}
+
+		if err := p.setJSONSchemaProp(key, val); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (p *property) setProp(key, val string) (err error) {
-	var iVal int
+func (p *property) setJSONSchemaProp(key, val string) error {
 	switch key {
 	case "minLength":
-		iVal, err = strconv.Atoi(val)
+		iVal, err := strconv.Atoi(val)
+		if err != nil {
+			return err
+		}
 		p.MinLength = &iVal
 	case "maxLength":
-		iVal, err = strconv.Atoi(val)
+		iVal, err := strconv.Atoi(val)
+		if err != nil {
+			return err
+		}
 		p.MaxLength = &iVal
+	case "pattern":
+		p.Pattern = val
+	case "minimum":
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return err
+		}
+		p.Minimum = &f
+	case "maximum":
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return err
+		}
+		p.Maximum = &f
+	case "exclusiveMinimum":
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return err
+		}
+		p.ExclusiveMinimum = &f
+	case "exclusiveMaximum":
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return err
+		}
+		p.ExclusiveMaximum = &f
+	case "multipleOf":
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return err
+		}
+		p.MultipleOf = &f
+	case "enum":
+		values, err := splitEnumValues(val, p.Type)
+		if err != nil {
+			return err
+		}
+		p.Enum = values
+	case "const":
+		v, err := coerceTagValue(val, p.Type)
+		if err != nil {
+			return err
+		}
+		p.Const = v
+	case "minItems":
+		iVal, err := strconv.Atoi(val)
+		if err != nil {
+			return err
+		}
+		p.MinItems = &iVal
+	case "maxItems":
+		iVal, err := strconv.Atoi(val)
+		if err != nil {
+			return err
+		}
+		p.MaxItems = &iVal
+	case "uniqueItems":
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		p.UniqueItems = b
+	case "minProperties":
+		iVal, err := strconv.Atoi(val)
+		if err != nil {
+			return err
+		}
+		p.MinProperties = &iVal
+	case "maxProperties":
+		iVal, err := strconv.Atoi(val)
+		if err != nil {
+			return err
+		}
+		p.MaxProperties = &iVal
+	case "default":
+		v, err := coerceTagValue(val, p.Type)
+		if err != nil {
+			return err
+		}
+		p.Default = v
+	case "title":
+		p.Title = val
+	case "description":
+		p.Description = val
+	case "readOnly":
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		p.ReadOnly = b
+	case "writeOnly":
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		p.WriteOnly = b
+	case "examples":
+		values, err := splitEnumValues(val, p.Type)
+		if err != nil {
+			return err
+		}
+		p.Examples = values
+	default:
+		return errors.New("unsupported jsonschema property " + key)
+	}
+	return nil
+}
+
+// splitEnumValues turns a `|`-separated tag value (e.g. "red|green|blue")
+// into the []interface{} shape the enum/examples keywords expect, coercing
+// each part to match propType so e.g. an int field's enum doesn't end up as
+// a list of strings alongside "type": "integer".
+func splitEnumValues(val, propType string) ([]interface{}, error) {
+	parts := strings.Split(val, "|")
+	values := make([]interface{}, len(parts))
+	for i, part := range parts {
+		v, err := coerceTagValue(part, propType)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// coerceTagValue parses a raw jsonschema tag value (const/default/enum
+// member) as the Go type propType resolved to, so the rendered schema
+// doesn't contradict itself (e.g. "type": "integer" next to "const": "5").
+// Unrecognized/string types pass the raw text through unchanged.
+func coerceTagValue(val, propType string) (interface{}, error) {
+	switch propType {
+	case "integer":
+		return strconv.ParseInt(val, 10, 64)
+	case "number":
+		return strconv.ParseFloat(val, 64)
+	case "boolean":
+		return strconv.ParseBool(val)
 	default:
-		err = errors.New("unsupported property " + key)
+		return val, nil
 	}
-	return err
 }
 
-func (p *property) applyOpts(opts tagOptions) {
-	sOpts := string(opts)
-	for _, v := range strings.Split(string(sOpts), ",") {
-		k := strings.Split(v, ":")
-		key := k[0]
-		val := ""
-		if len(k) > 1 {
-			val = k[1]
+// toMap renders p as the plain map encoding/json will turn into the JSON
+// object for this property, honoring draft-specific shapes: numeric vs.
+// boolean exclusiveMinimum/Maximum, and prefixItems vs. an items-tuple for
+// fixed-length arrays. A $ref node carries nothing else, per the spec.
+func (p *property) toMap(draft Draft) map[string]interface{} {
+	m := make(map[string]interface{})
+
+	if p.Ref != "" {
+		m["$ref"] = p.Ref
+		return m
+	}
+
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+	if p.Format != "" {
+		m["format"] = p.Format
+	}
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+	if p.Description != "" {
+		m["description"] = p.Description
+	}
+
+	if p.MinLength != nil {
+		m["minLength"] = *p.MinLength
+	}
+	if p.MaxLength != nil {
+		m["maxLength"] = *p.MaxLength
+	}
+	if p.Pattern != "" {
+		m["pattern"] = p.Pattern
+	}
+
+	if p.Minimum != nil {
+		m["minimum"] = *p.Minimum
+	}
+	if p.Maximum != nil {
+		m["maximum"] = *p.Maximum
+	}
+	applyExclusiveBound(m, "minimum", "exclusiveMinimum", p.ExclusiveMinimum, draft)
+	applyExclusiveBound(m, "maximum", "exclusiveMaximum", p.ExclusiveMaximum, draft)
+	if p.MultipleOf != nil {
+		m["multipleOf"] = *p.MultipleOf
+	}
+
+	if len(p.Enum) > 0 {
+		m["enum"] = p.Enum
+	}
+	if p.Const != nil && draft != Draft04 {
+		// const was only introduced in draft-06.
+		m["const"] = p.Const
+	}
+
+	if p.arrayLen > 0 && p.Items != nil {
+		tuple := make([]interface{}, p.arrayLen)
+		elem := p.Items.toMap(draft)
+		for i := range tuple {
+			tuple[i] = elem
+		}
+		if draft == Draft2020_12 {
+			m["prefixItems"] = tuple
+		} else {
+			m["items"] = tuple
+		}
+	} else if p.Items != nil {
+		m["items"] = p.Items.toMap(draft)
+	}
+	if p.MinItems != nil {
+		m["minItems"] = *p.MinItems
+	}
+	if p.MaxItems != nil {
+		m["maxItems"] = *p.MaxItems
+	}
+	if p.UniqueItems {
+		m["uniqueItems"] = true
+	}
+
+	if len(p.Properties) > 0 {
+		props := make(map[string]interface{}, len(p.Properties))
+		for name, child := range p.Properties {
+			props[name] = child.toMap(draft)
+		}
+		m["properties"] = props
+	}
+	if len(p.Required) > 0 {
+		m["required"] = p.Required
+	}
+	if p.AdditionalProperties {
+		m["additionalProperties"] = true
+	}
+	if p.MinProperties != nil {
+		m["minProperties"] = *p.MinProperties
+	}
+	if p.MaxProperties != nil {
+		m["maxProperties"] = *p.MaxProperties
+	}
+
+	if p.Default != nil {
+		m["default"] = p.Default
+	}
+	// examples, readOnly and writeOnly are draft-06/07+ keywords; draft-04
+	// validators are entitled to reject unknown keywords.
+	if len(p.Examples) > 0 && draft != Draft04 {
+		m["examples"] = p.Examples
+	}
+	if p.ReadOnly && draft != Draft04 {
+		m["readOnly"] = true
+	}
+	if p.WriteOnly && draft != Draft04 {
+		m["writeOnly"] = true
+	}
+
+	return m
+}
+
+// applyExclusiveBound emits an exclusive bound in whichever shape the draft
+// expects: draft-04 represents it as a boolean modifier on minimum/maximum,
+// draft-06+ as its own numeric keyword.
+func applyExclusiveBound(m map[string]interface{}, boundKey, exclusiveKey string, exclusive *float64, draft Draft) {
+	if exclusive == nil {
+		return
+	}
+	if draft == Draft04 {
+		m[boundKey] = *exclusive
+		m[exclusiveKey] = true
+		return
+	}
+	m[exclusiveKey] = *exclusive
+}
+
+// resolveOverride consults a caller-registered type, in order of precedence:
+// Document.RegisterType entries, then a WithTypeMapper TypeMapper. Either one
+// short-circuits the default kind/format-table resolution below.
+func resolveOverride(t reflect.Type, ctx *readContext) (*property, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	if def, ok := ctx.registry[t]; ok {
+		return def, true
+	}
+	if ctx.mapper != nil {
+		if def, ok := ctx.mapper.MapType(t); ok {
+			return def, true
 		}
-		_ = p.setProp(key, val)
 	}
+	return nil, false
 }
 
+// formatMapping holds built-in schemas for types that are better described
+// by a string format than by walking their fields/kind. Keyed by
+// reflect.Type.String() so types we'd rather not import directly (e.g.
+// uuid.UUID) can still be recognized by name.
 var formatMapping = map[string][]string{
-	"time.Time": {"string", "date-time"},
+	"time.Time":       {"string", "date-time"},
+	"time.Duration":   {"string", "duration"},
+	"net.IP":          {"string", "ipv4"},
+	"*url.URL":        {"string", "uri"},
+	"*regexp.Regexp":  {"string", "regex"},
+	"uuid.UUID":       {"string", "uuid"},
+	"json.RawMessage": {"", ""},
 }
 
 var kindMapping = map[reflect.Kind]string{
@@ -0,0 +1,106 @@
+package jsonschema_test
+
+import (
+	"testing"
+
+	jsonschema "github.com/wshaman/go-jsonschema-generator"
+)
+
+type nestedTag struct {
+	Name string
+}
+
+type nestedShapes struct {
+	Tags    map[string][]*nestedTag   `json:"tags"`
+	Strings *[]*nestedTag             `json:"strings"`
+	Matrix  [][]int                   `json:"matrix"`
+	Grid    map[string]map[string]int `json:"grid"`
+	Fixed   [3]int                    `json:"fixed"`
+}
+
+// TestReadNestedCompositions exercises the nested slice/map/pointer shapes
+// resolveElem was added to handle: map[string][]*T, *[]*T, [][]T and
+// map[string]map[string]T should all resolve to real schemas instead of
+// bottoming out as an untyped "object".
+func TestReadNestedCompositions(t *testing.T) {
+	doc := &jsonschema.Document{}
+	if err := doc.Read(&nestedShapes{}); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	tags := doc.Properties["tags"]
+	if tags == nil || tags.Type != "object" {
+		t.Fatalf("tags: want object, got %+v", tags)
+	}
+	tagsValue := tags.Properties[".*"]
+	if tagsValue == nil || tagsValue.Type != "array" {
+		t.Fatalf("tags value: want array, got %+v", tagsValue)
+	}
+	if tagsValue.Items == nil || tagsValue.Items.Ref == "" {
+		t.Fatalf("tags value items: want a $ref, got %+v", tagsValue.Items)
+	}
+
+	strings := doc.Properties["strings"]
+	if strings == nil || strings.Type != "array" {
+		t.Fatalf("strings: want array, got %+v", strings)
+	}
+	if strings.Items == nil || strings.Items.Ref == "" {
+		t.Fatalf("strings items: want a $ref, got %+v", strings.Items)
+	}
+
+	matrix := doc.Properties["matrix"]
+	if matrix == nil || matrix.Type != "array" {
+		t.Fatalf("matrix: want array, got %+v", matrix)
+	}
+	if matrix.Items == nil || matrix.Items.Type != "array" {
+		t.Fatalf("matrix items: want array, got %+v", matrix.Items)
+	}
+	if matrix.Items.Items == nil || matrix.Items.Items.Type != "integer" {
+		t.Fatalf("matrix items items: want integer, got %+v", matrix.Items.Items)
+	}
+
+	grid := doc.Properties["grid"]
+	if grid == nil || grid.Type != "object" {
+		t.Fatalf("grid: want object, got %+v", grid)
+	}
+	gridValue := grid.Properties[".*"]
+	if gridValue == nil || gridValue.Type != "object" {
+		t.Fatalf("grid value: want object, got %+v", gridValue)
+	}
+	gridInner := gridValue.Properties[".*"]
+	if gridInner == nil || gridInner.Type != "integer" {
+		t.Fatalf("grid inner value: want integer, got %+v", gridInner)
+	}
+
+	// The entry type (nestedShapes) must stay inline, not $ref'd into $defs,
+	// while nestedTag - referenced twice above - must be.
+	if _, ok := doc.Definitions["nestedShapes"]; ok {
+		t.Fatalf("entry type should not appear in $defs, got %+v", doc.Definitions)
+	}
+	if _, ok := doc.Definitions["nestedTag"]; !ok {
+		t.Fatalf("nestedTag: want a $defs entry, got %+v", doc.Definitions)
+	}
+}
+
+// TestReadFixedArray checks that a Go array, unlike a slice, pins down
+// minItems/maxItems to its fixed length.
+func TestReadFixedArray(t *testing.T) {
+	doc := &jsonschema.Document{}
+	if err := doc.Read(&nestedShapes{}); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	fixed := doc.Properties["fixed"]
+	if fixed == nil || fixed.Type != "array" {
+		t.Fatalf("fixed: want array, got %+v", fixed)
+	}
+	if fixed.MinItems == nil || *fixed.MinItems != 3 {
+		t.Fatalf("fixed.MinItems: want 3, got %v", fixed.MinItems)
+	}
+	if fixed.MaxItems == nil || *fixed.MaxItems != 3 {
+		t.Fatalf("fixed.MaxItems: want 3, got %v", fixed.MaxItems)
+	}
+	if fixed.Items == nil || fixed.Items.Type != "integer" {
+		t.Fatalf("fixed.Items: want integer, got %+v", fixed.Items)
+	}
+}